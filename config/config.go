@@ -0,0 +1,175 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetadataBackend selects where cluster metadata (topics, brokers, partition
+// counts) is read from.
+type MetadataBackend string
+
+const (
+	// BackendKafka reads metadata exclusively through the Kafka admin API.
+	// Works against KRaft-mode clusters that have no Zookeeper.
+	BackendKafka MetadataBackend = "kafka"
+	// BackendZookeeper reads metadata exclusively from Zookeeper znodes,
+	// matching the dashboard's original behavior.
+	BackendZookeeper MetadataBackend = "zookeeper"
+	// BackendAuto prefers the Kafka admin API and falls back to Zookeeper
+	// when a Kafka metadata call fails and a Zookeeper connection is
+	// available.
+	BackendAuto MetadataBackend = "auto"
+)
+
+// SASLConfig holds SASL authentication settings for connecting to a secured
+// Kafka cluster.
+type SASLConfig struct {
+	Enabled   bool
+	Mechanism string // PLAIN, SCRAM-SHA-256, or SCRAM-SHA-512
+	User      string
+	Password  string
+}
+
+// TLSConfig holds TLS settings for connecting to a secured Kafka cluster.
+type TLSConfig struct {
+	Enabled            bool
+	CertFile           string
+	KeyFile            string
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+// Config holds the dashboard's runtime configuration, populated from
+// environment variables.
+type Config struct {
+	KafkaBrokers    string
+	ZookeeperNodes  string
+	HTTPPort        string
+	MetadataBackend MetadataBackend
+	SASL            SASLConfig
+	TLS             TLSConfig
+
+	// LagCacheTTL bounds how often consumer-group lag is recomputed from
+	// the brokers; repeated lookups within the TTL reuse the cached
+	// result.
+	LagCacheTTL time.Duration
+
+	// Decoders maps topic name to a registered decoder name ("raw",
+	// "string", "json", "msgpack", "avro", or "protobuf"). Topics with no
+	// entry use DefaultDecoder.
+	Decoders       map[string]string
+	DefaultDecoder string
+
+	// SchemaRegistryURL, when set, enables the "avro" decoder against a
+	// Confluent-compatible Schema Registry.
+	SchemaRegistryURL       string
+	SchemaRegistryCacheSize int
+
+	// ProtoDescriptorSetPath, when set, enables the "protobuf" decoder.
+	// ProtoMessageTypes maps topic name to the fully-qualified message
+	// type (e.g. "orders.v1.Order") to decode that topic's payloads as.
+	ProtoDescriptorSetPath string
+	ProtoMessageTypes      map[string]string
+
+	// LogLevel is one of "debug", "info", "warn", or "error".
+	LogLevel string
+}
+
+// NewConfig builds a Config from environment variables, applying sane
+// defaults for local development.
+func NewConfig() (*Config, error) {
+	cfg := &Config{
+		KafkaBrokers:    getEnv("KAFKA_BROKERS", "localhost:9092"),
+		ZookeeperNodes:  getEnv("ZOOKEEPER_NODES", "localhost:2181"),
+		HTTPPort:        getEnv("HTTP_PORT", "8080"),
+		MetadataBackend: MetadataBackend(strings.ToLower(getEnv("METADATA_BACKEND", string(BackendAuto)))),
+		SASL: SASLConfig{
+			Enabled:   getEnvBool("KAFKA_SASL_ENABLED", false),
+			Mechanism: getEnv("KAFKA_SASL_MECHANISM", "PLAIN"),
+			User:      getEnv("KAFKA_SASL_USER", ""),
+			Password:  getEnv("KAFKA_SASL_PASSWORD", ""),
+		},
+		TLS: TLSConfig{
+			Enabled:            getEnvBool("KAFKA_TLS_ENABLED", false),
+			CertFile:           getEnv("KAFKA_TLS_CERT_FILE", ""),
+			KeyFile:            getEnv("KAFKA_TLS_KEY_FILE", ""),
+			CAFile:             getEnv("KAFKA_TLS_CA_FILE", ""),
+			InsecureSkipVerify: getEnvBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false),
+		},
+		LagCacheTTL: time.Duration(getEnvInt("LAG_CACHE_TTL_SECONDS", 5)) * time.Second,
+
+		Decoders:       parseMapEnv("TOPIC_DECODERS"),
+		DefaultDecoder: getEnv("DEFAULT_DECODER", "raw"),
+
+		SchemaRegistryURL:       getEnv("SCHEMA_REGISTRY_URL", ""),
+		SchemaRegistryCacheSize: getEnvInt("SCHEMA_REGISTRY_CACHE_SIZE", 256),
+
+		ProtoDescriptorSetPath: getEnv("PROTO_DESCRIPTOR_SET_PATH", ""),
+		ProtoMessageTypes:      parseMapEnv("PROTO_MESSAGE_TYPES"),
+
+		LogLevel: strings.ToLower(getEnv("LOG_LEVEL", "info")),
+	}
+
+	switch cfg.MetadataBackend {
+	case BackendKafka, BackendZookeeper, BackendAuto:
+	default:
+		return nil, fmt.Errorf("invalid METADATA_BACKEND %q: must be kafka, zookeeper, or auto", cfg.MetadataBackend)
+	}
+
+	return cfg, nil
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return fallback
+	}
+	return i
+}
+
+// parseMapEnv parses a "key1=value1,key2=value2" environment variable into
+// a map, e.g. TOPIC_DECODERS="orders=avro,clicks=json".
+func parseMapEnv(key string) map[string]string {
+	result := make(map[string]string)
+
+	raw := os.Getenv(key)
+	if raw == "" {
+		return result
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		result[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return result
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
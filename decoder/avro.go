@@ -0,0 +1,86 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/linkedin/goavro/v2"
+)
+
+// SchemaRegistryClient fetches and caches Avro schemas from a Confluent
+// Schema Registry, keyed by schema ID.
+type SchemaRegistryClient struct {
+	baseURL string
+	http    *http.Client
+	cache   *lru.Cache[int, *goavro.Codec]
+}
+
+// NewSchemaRegistryClient builds a client that caches up to cacheSize
+// resolved schemas in memory.
+func NewSchemaRegistryClient(baseURL string, cacheSize int) (*SchemaRegistryClient, error) {
+	cache, err := lru.New[int, *goavro.Codec](cacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("create schema cache: %w", err)
+	}
+	return &SchemaRegistryClient{baseURL: baseURL, http: &http.Client{}, cache: cache}, nil
+}
+
+func (c *SchemaRegistryClient) codecForID(id int) (*goavro.Codec, error) {
+	if codec, ok := c.cache.Get(id); ok {
+		return codec, nil
+	}
+
+	resp, err := c.http.Get(fmt.Sprintf("%s/schemas/ids/%d", c.baseURL, id))
+	if err != nil {
+		return nil, fmt.Errorf("fetch schema %d: %w", id, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("fetch schema %d: schema registry returned %d: %s", id, resp.StatusCode, body)
+	}
+
+	var payload struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode schema %d response: %w", id, err)
+	}
+
+	codec, err := goavro.NewCodec(payload.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("parse schema %d: %w", id, err)
+	}
+
+	c.cache.Add(id, codec)
+	return codec, nil
+}
+
+// AvroDecoder decodes Confluent-framed Avro: a leading 0x00 magic byte, a
+// 4-byte big-endian schema ID, then the Avro binary payload.
+type AvroDecoder struct {
+	Registry *SchemaRegistryClient
+}
+
+func (d AvroDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	if len(raw) < 5 || raw[0] != 0x00 {
+		return nil, fmt.Errorf("not a Confluent-framed Avro payload")
+	}
+
+	schemaID := int(binary.BigEndian.Uint32(raw[1:5]))
+	codec, err := d.Registry.codecForID(schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := codec.NativeFromBinary(raw[5:])
+	if err != nil {
+		return nil, fmt.Errorf("decode avro payload for schema %d: %w", schemaID, err)
+	}
+	return native, nil
+}
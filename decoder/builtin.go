@@ -0,0 +1,29 @@
+package decoder
+
+import "encoding/json"
+
+// RawDecoder returns the payload unchanged. encoding/json base64-encodes
+// []byte values, so the payload reaches the client as a base64 string.
+type RawDecoder struct{}
+
+func (RawDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	return raw, nil
+}
+
+// StringDecoder treats the payload as UTF-8 text.
+type StringDecoder struct{}
+
+func (StringDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	return string(raw), nil
+}
+
+// JSONDecoder unmarshals the payload as JSON.
+type JSONDecoder struct{}
+
+func (JSONDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
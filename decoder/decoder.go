@@ -0,0 +1,107 @@
+// Package decoder turns raw Kafka record keys/values into JSON-friendly
+// representations, with per-topic decoder selection and transparent
+// decompression of Snappy/Gzip/LZ4-framed payloads.
+package decoder
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Decoder turns a raw key or value payload into a JSON-friendly value.
+type Decoder interface {
+	Decode(topic string, isKey bool, raw []byte) (any, error)
+}
+
+// Registry selects a Decoder per topic, with a default for topics that
+// have no explicit assignment.
+type Registry struct {
+	decoders      map[string]Decoder
+	topicDecoders map[string]string
+	defaultName   string
+}
+
+// NewRegistry builds a Registry. defaultName, and every name in
+// topicDecoders, must be a key of decoders: validating topicDecoders here
+// too means a misconfigured topic decoder (e.g. "avro" with no schema
+// registry configured) fails fast at startup instead of spamming "decoder
+// is not registered" on every message for that topic at runtime.
+func NewRegistry(decoders map[string]Decoder, topicDecoders map[string]string, defaultName string) (*Registry, error) {
+	if _, ok := decoders[defaultName]; !ok {
+		return nil, fmt.Errorf("default decoder %q is not registered", defaultName)
+	}
+	for topic, name := range topicDecoders {
+		if _, ok := decoders[name]; !ok {
+			return nil, fmt.Errorf("decoder %q configured for topic %q is not registered", name, topic)
+		}
+	}
+	return &Registry{decoders: decoders, topicDecoders: topicDecoders, defaultName: defaultName}, nil
+}
+
+// For returns the Decoder configured for topic, falling back to the
+// registry default.
+func (r *Registry) For(topic string) (Decoder, error) {
+	name := r.defaultName
+	if configured, ok := r.topicDecoders[topic]; ok {
+		name = configured
+	}
+
+	d, ok := r.decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("decoder %q is not registered", name)
+	}
+	return d, nil
+}
+
+// Decode transparently inflates raw if it is Snappy/Gzip/LZ4-framed, then
+// decodes it with whichever Decoder is configured for topic. The method
+// signature matches streaming.DecodeFunc so a *Registry can be passed
+// straight into streaming.Options.Decode.
+func (r *Registry) Decode(topic string, isKey bool, raw []byte) (any, error) {
+	d, err := r.For(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	inflated, err := inflate(raw)
+	if err != nil {
+		return nil, fmt.Errorf("inflate payload: %w", err)
+	}
+
+	return d.Decode(topic, isKey, inflated)
+}
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	lz4Magic          = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyStreamMagic = []byte("\xff\x06\x00\x00sNaPpY")
+)
+
+// inflate detects Gzip, LZ4 frame, and Snappy framed-stream magic bytes
+// and decompresses accordingly. Anything else is returned unchanged, so
+// plain (uncompressed) payloads pay no extra cost.
+func inflate(raw []byte) ([]byte, error) {
+	switch {
+	case len(raw) >= len(gzipMagic) && bytes.Equal(raw[:len(gzipMagic)], gzipMagic):
+		r, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+
+	case len(raw) >= len(lz4Magic) && bytes.Equal(raw[:len(lz4Magic)], lz4Magic):
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(raw)))
+
+	case len(raw) >= len(snappyStreamMagic) && bytes.Equal(raw[:len(snappyStreamMagic)], snappyStreamMagic):
+		return io.ReadAll(snappy.NewReader(bytes.NewReader(raw)))
+
+	default:
+		return raw, nil
+	}
+}
@@ -0,0 +1,44 @@
+package decoder
+
+import "testing"
+
+type fakeDecoder struct{ name string }
+
+func (fakeDecoder) Decode(topic string, isKey bool, raw []byte) (any, error) {
+	return string(raw), nil
+}
+
+func TestNewRegistryRejectsUnregisteredDefault(t *testing.T) {
+	_, err := NewRegistry(map[string]Decoder{"json": fakeDecoder{}}, nil, "avro")
+	if err == nil {
+		t.Fatal("expected an error for an unregistered default decoder")
+	}
+}
+
+func TestNewRegistryRejectsUnregisteredTopicDecoder(t *testing.T) {
+	decoders := map[string]Decoder{"json": fakeDecoder{}}
+	topicDecoders := map[string]string{"orders": "avro"}
+
+	_, err := NewRegistry(decoders, topicDecoders, "json")
+	if err == nil {
+		t.Fatal("expected an error for an unregistered topic decoder")
+	}
+}
+
+func TestNewRegistryAcceptsValidConfig(t *testing.T) {
+	decoders := map[string]Decoder{"json": fakeDecoder{name: "json"}, "raw": fakeDecoder{name: "raw"}}
+	topicDecoders := map[string]string{"orders": "raw"}
+
+	r, err := NewRegistry(decoders, topicDecoders, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, err := r.For("orders")
+	if err != nil {
+		t.Fatalf("For(orders): %v", err)
+	}
+	if d != decoders["raw"] {
+		t.Fatal("expected For(orders) to return the configured raw decoder")
+	}
+}
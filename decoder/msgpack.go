@@ -0,0 +1,14 @@
+package decoder
+
+import "github.com/vmihailenco/msgpack/v5"
+
+// MsgpackDecoder unmarshals the payload as MessagePack.
+type MsgpackDecoder struct{}
+
+func (MsgpackDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	var v any
+	if err := msgpack.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
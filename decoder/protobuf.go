@@ -0,0 +1,85 @@
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ProtobufTypes resolves message types, by fully-qualified name (e.g.
+// "orders.v1.Order"), from a FileDescriptorSet loaded at startup.
+type ProtobufTypes struct {
+	files *protoregistry.Files
+}
+
+// LoadProtobufTypes reads a serialized FileDescriptorSet, as produced by
+// `protoc --descriptor_set_out`, from path.
+func LoadProtobufTypes(path string) (*ProtobufTypes, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read descriptor set %s: %w", path, err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse descriptor set %s: %w", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("build file registry from %s: %w", path, err)
+	}
+
+	return &ProtobufTypes{files: files}, nil
+}
+
+func (t *ProtobufTypes) messageType(name string) (protoreflect.MessageType, error) {
+	desc, err := t.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("find message type %s: %w", name, err)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", name)
+	}
+	return dynamicpb.NewMessageType(msgDesc), nil
+}
+
+// ProtobufDecoder decodes a payload against a single named message type.
+type ProtobufDecoder struct {
+	Types       *ProtobufTypes
+	MessageName string
+}
+
+func (d ProtobufDecoder) Decode(_ string, _ bool, raw []byte) (any, error) {
+	msgType, err := d.Types.messageType(d.MessageName)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := msgType.New().Interface()
+	if err := proto.Unmarshal(raw, msg); err != nil {
+		return nil, fmt.Errorf("decode protobuf message %s: %w", d.MessageName, err)
+	}
+
+	// Round-trip through protojson so the result is plain JSON-friendly
+	// data, matching what the other decoders return.
+	jsonBytes, err := protojson.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("convert protobuf message %s to JSON: %w", d.MessageName, err)
+	}
+
+	var v any
+	if err := json.Unmarshal(jsonBytes, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/umerfarok/kafka-live-dashboard/config"
+	"github.com/umerfarok/kafka-live-dashboard/decoder"
+)
+
+// buildDecoderRegistry wires up the decoders named in cfg.Decoders,
+// enabling Avro and Protobuf support only when their respective config is
+// present.
+func buildDecoderRegistry(cfg *config.Config) (*decoder.Registry, error) {
+	decoders := map[string]decoder.Decoder{
+		"raw":     decoder.RawDecoder{},
+		"string":  decoder.StringDecoder{},
+		"json":    decoder.JSONDecoder{},
+		"msgpack": decoder.MsgpackDecoder{},
+	}
+
+	if cfg.SchemaRegistryURL != "" {
+		registryClient, err := decoder.NewSchemaRegistryClient(cfg.SchemaRegistryURL, cfg.SchemaRegistryCacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("create schema registry client: %w", err)
+		}
+		decoders["avro"] = decoder.AvroDecoder{Registry: registryClient}
+	}
+
+	// Protobuf needs a message type per topic, not just a decoder name, so
+	// each configured topic gets its own registry entry keyed
+	// "protobuf:<topic>" and Decoders["<topic>"] = "protobuf" is rewritten
+	// below to point at it.
+	protobufDecoders := map[string]decoder.Decoder{}
+	if cfg.ProtoDescriptorSetPath != "" {
+		types, err := decoder.LoadProtobufTypes(cfg.ProtoDescriptorSetPath)
+		if err != nil {
+			return nil, fmt.Errorf("load protobuf descriptor set: %w", err)
+		}
+		for topic, messageName := range cfg.ProtoMessageTypes {
+			name := "protobuf:" + topic
+			decoders[name] = decoder.ProtobufDecoder{Types: types, MessageName: messageName}
+			protobufDecoders[topic] = decoders[name]
+		}
+	}
+
+	topicDecoders := make(map[string]string, len(cfg.Decoders))
+	for topic, name := range cfg.Decoders {
+		if name != "protobuf" {
+			topicDecoders[topic] = name
+			continue
+		}
+		if _, ok := protobufDecoders[topic]; !ok {
+			return nil, fmt.Errorf("topic %s configured for protobuf decoding but has no entry in ProtoMessageTypes", topic)
+		}
+		topicDecoders[topic] = "protobuf:" + topic
+	}
+
+	return decoder.NewRegistry(decoders, topicDecoders, cfg.DefaultDecoder)
+}
@@ -0,0 +1,216 @@
+// Package filter compiles websocket query parameters into predicates that
+// decide whether a decoded Kafka message should be forwarded to a client.
+// It knows nothing about sarama or the streaming package; callers adapt its
+// Record/Predicate types to whatever envelope shape they stream.
+package filter
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// Record is the view of a message a Predicate is evaluated against. Its
+// fields carry the same data as the JSON envelope the dashboard sends over
+// the websocket, but expressions refer to them by their Go field names
+// (e.g. `Value.status == "failed"`), not the envelope's lowercase JSON keys.
+type Record struct {
+	Key       any
+	Value     any
+	Headers   map[string]string
+	Partition int32
+	Offset    int64
+	Timestamp time.Time
+}
+
+// Predicate reports whether a Record should be forwarded.
+type Predicate func(Record) bool
+
+// evalBudget caps how long a single expression predicate may run against one
+// message. expr-lang/expr has no built-in way to cancel a Run mid-flight, so
+// a runaway expression (e.g. a pathological regex) is bounded by racing it
+// against a timer instead; a message that times out is dropped. A var, not
+// a const, so tests can shrink it to deterministically exercise the timeout
+// path without needing a genuinely slow expression.
+var evalBudget = 5 * time.Millisecond
+
+// maxExprNodes bounds how large a compiled expression's AST may be, so a
+// single filter= value can't force every subsequent message evaluation to
+// walk an enormous program. This is a real, compile-time complexity cap,
+// distinct from evalBudget which only bounds a single run's wall-clock time.
+const maxExprNodes = 200
+
+// maxConsecutiveTimeouts is how many evalBudget timeouts in a row an
+// expression predicate tolerates before it stops evaluating entirely. A
+// goroutine racing a timed-out expr.Run is abandoned, not canceled (expr has
+// no cancellation hook), so an attacker who keeps a /ws connection open with
+// a pathological filter= could otherwise pile up one live, CPU-spinning
+// goroutine per message forever. Tripping the breaker bounds that to a
+// small, fixed number instead of letting it grow for the life of the
+// connection.
+const maxConsecutiveTimeouts = 3
+
+// FromQuery builds a Predicate from a websocket request's query parameters.
+// Two forms are supported and combined with AND when both are present:
+//
+//   - Shorthand: key=<val>, header.<Name>=<val>, and contains=<substr> match
+//     against the message's key, a specific header, and the stringified
+//     value respectively.
+//   - Expression: filter=<expr> is compiled with expr-lang/expr and evaluated
+//     against a Record, e.g. filter=Value.status == "failed" && Partition == 0.
+//
+// A request with none of these parameters gets a Predicate that matches
+// everything.
+func FromQuery(values url.Values) (Predicate, error) {
+	var predicates []Predicate
+
+	if want := values.Get("key"); want != "" {
+		predicates = append(predicates, func(r Record) bool {
+			return fmt.Sprint(r.Key) == want
+		})
+	}
+
+	if substr := values.Get("contains"); substr != "" {
+		predicates = append(predicates, func(r Record) bool {
+			return strings.Contains(fmt.Sprint(r.Value), substr)
+		})
+	}
+
+	for name, vals := range values {
+		header, ok := strings.CutPrefix(name, "header.")
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		want := vals[0]
+		predicates = append(predicates, func(r Record) bool {
+			return r.Headers[header] == want
+		})
+	}
+
+	if source := values.Get("filter"); source != "" {
+		compiled, err := compileExpr(source)
+		if err != nil {
+			return nil, fmt.Errorf("compile filter expression: %w", err)
+		}
+		predicates = append(predicates, compiled)
+	}
+
+	if len(predicates) == 0 {
+		return func(Record) bool { return true }, nil
+	}
+
+	return func(r Record) bool {
+		for _, p := range predicates {
+			if !p(r) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func compileExpr(source string) (Predicate, error) {
+	program, err := expr.Compile(source, expr.Env(Record{}), expr.AsBool(), expr.MaxNodes(maxExprNodes))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprPredicate{program: program}
+	return p.eval, nil
+}
+
+// exprPredicate holds the per-connection state (the consecutive-timeout
+// breaker) for one compiled filter= expression.
+type exprPredicate struct {
+	program *vm.Program
+
+	mu                 sync.Mutex
+	consecutiveTimeout int
+	disabled           bool
+}
+
+func (p *exprPredicate) eval(r Record) bool {
+	p.mu.Lock()
+	if p.disabled {
+		p.mu.Unlock()
+		return false
+	}
+	p.mu.Unlock()
+
+	result, ok := runWithBudget(p.program, r)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !ok {
+		p.consecutiveTimeout++
+		if p.consecutiveTimeout >= maxConsecutiveTimeouts {
+			p.disabled = true
+			slog.Warn("filter: disabling expression after repeated timeouts", "timeouts", p.consecutiveTimeout)
+		}
+		return false
+	}
+	p.consecutiveTimeout = 0
+	matched, _ := result.(bool)
+	return matched
+}
+
+func runWithBudget(program *vm.Program, r Record) (any, bool) {
+	done := make(chan any, 1)
+	go func() {
+		result, err := expr.Run(program, r)
+		if err != nil {
+			// A runtime error is a non-match, not a timeout, so it
+			// does not count towards the breaker.
+			done <- false
+			return
+		}
+		done <- result
+	}()
+
+	select {
+	case result := <-done:
+		return result, result != nil
+	case <-time.After(evalBudget):
+		return nil, false
+	}
+}
+
+// Sample returns a Predicate that lets roughly one in n messages through,
+// for viewing high-throughput topics without overwhelming a browser
+// websocket client. n<=1 matches everything.
+func Sample(n int) Predicate {
+	if n <= 1 {
+		return func(Record) bool { return true }
+	}
+	threshold := 1 / float64(n)
+	return func(Record) bool {
+		return rand.Float64() < threshold
+	}
+}
+
+// ParseSampleRate parses a "sample=1/N" query parameter into N. An empty or
+// absent value returns 1 (no downsampling).
+func ParseSampleRate(raw string) (int, error) {
+	if raw == "" {
+		return 1, nil
+	}
+
+	_, denominator, ok := strings.Cut(raw, "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid sample rate %q: want 1/N", raw)
+	}
+
+	n, err := strconv.Atoi(denominator)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid sample rate %q: want 1/N", raw)
+	}
+	return n, nil
+}
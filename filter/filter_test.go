@@ -0,0 +1,181 @@
+package filter
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/expr-lang/expr"
+)
+
+func TestFromQueryShorthand(t *testing.T) {
+	cases := []struct {
+		name   string
+		query  string
+		record Record
+		want   bool
+	}{
+		{
+			name:   "key match",
+			query:  "key=abc",
+			record: Record{Key: "abc"},
+			want:   true,
+		},
+		{
+			name:   "key mismatch",
+			query:  "key=abc",
+			record: Record{Key: "xyz"},
+			want:   false,
+		},
+		{
+			name:   "header match",
+			query:  "header.trace-id=t-1",
+			record: Record{Headers: map[string]string{"trace-id": "t-1"}},
+			want:   true,
+		},
+		{
+			name:   "header mismatch",
+			query:  "header.trace-id=t-1",
+			record: Record{Headers: map[string]string{"trace-id": "t-2"}},
+			want:   false,
+		},
+		{
+			name:   "contains match",
+			query:  "contains=fail",
+			record: Record{Value: "order failed to process"},
+			want:   true,
+		},
+		{
+			name:   "contains mismatch",
+			query:  "contains=fail",
+			record: Record{Value: "order processed"},
+			want:   false,
+		},
+		{
+			name:   "key and contains both required",
+			query:  "key=abc&contains=fail",
+			record: Record{Key: "abc", Value: "order processed"},
+			want:   false,
+		},
+		{
+			name:   "no params matches everything",
+			query:  "",
+			record: Record{},
+			want:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			if err != nil {
+				t.Fatalf("ParseQuery(%q): %v", tc.query, err)
+			}
+
+			predicate, err := FromQuery(values)
+			if err != nil {
+				t.Fatalf("FromQuery(%q): %v", tc.query, err)
+			}
+
+			if got := predicate(tc.record); got != tc.want {
+				t.Fatalf("FromQuery(%q)(%+v) = %v, want %v", tc.query, tc.record, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFromQueryExpression(t *testing.T) {
+	values, err := url.ParseQuery(`filter=Partition == 0 && Value == "ok"`)
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+
+	predicate, err := FromQuery(values)
+	if err != nil {
+		t.Fatalf("FromQuery: %v", err)
+	}
+
+	if !predicate(Record{Partition: 0, Value: "ok"}) {
+		t.Fatal("expected matching record to pass")
+	}
+	if predicate(Record{Partition: 1, Value: "ok"}) {
+		t.Fatal("expected non-matching partition to be rejected")
+	}
+}
+
+func TestFromQueryExpressionCompileError(t *testing.T) {
+	values, err := url.ParseQuery("filter=" + url.QueryEscape("not valid expr $$"))
+	if err != nil {
+		t.Fatalf("ParseQuery: %v", err)
+	}
+
+	if _, err := FromQuery(values); err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+}
+
+// TestExprPredicateTripsBreakerAfterRepeatedTimeouts guards against the
+// unbounded goroutine growth a pathological filter= expression could cause:
+// once an expression times out maxConsecutiveTimeouts times in a row, the
+// breaker must trip and stop spawning further evaluation goroutines.
+//
+// evalBudget is shrunk to effectively zero so that an ordinary (fast)
+// expression reliably "times out" without needing a genuinely slow one;
+// expr-lang/expr's own MaxNodes and memory-budget guards make a
+// deterministically slow-but-not-erroring expression impractical to
+// construct here.
+func TestExprPredicateTripsBreakerAfterRepeatedTimeouts(t *testing.T) {
+	original := evalBudget
+	evalBudget = 1
+	defer func() { evalBudget = original }()
+
+	program, err := expr.Compile("Partition == 0", expr.Env(Record{}))
+	if err != nil {
+		t.Fatalf("expr.Compile: %v", err)
+	}
+	p := &exprPredicate{program: program}
+
+	for i := 0; i < maxConsecutiveTimeouts; i++ {
+		if p.eval(Record{}) {
+			t.Fatalf("call %d: expected timed-out expression to report false", i)
+		}
+	}
+
+	p.mu.Lock()
+	disabled := p.disabled
+	p.mu.Unlock()
+	if !disabled {
+		t.Fatal("expected breaker to trip after maxConsecutiveTimeouts timeouts")
+	}
+}
+
+func TestParseSampleRate(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "", want: 1},
+		{raw: "1/10", want: 10},
+		{raw: "1/1", want: 1},
+		{raw: "bogus", wantErr: true},
+		{raw: "1/0", wantErr: true},
+		{raw: "1/-5", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseSampleRate(tc.raw)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("ParseSampleRate(%q): expected error, got n=%d", tc.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSampleRate(%q): unexpected error: %v", tc.raw, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("ParseSampleRate(%q) = %d, want %d", tc.raw, got, tc.want)
+		}
+	}
+}
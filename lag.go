@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// PartitionLag is one consumer group's lag on a single partition of a
+// topic.
+type PartitionLag struct {
+	Partition       int32
+	LogEndOffset    int64
+	CommittedOffset int64
+	Lag             int64
+}
+
+// GroupLag is a consumer group's per-partition lag across all of a
+// topic's partitions.
+type GroupLag struct {
+	Group      string
+	Partitions []PartitionLag
+	TotalLag   int64
+}
+
+// TopicLag is the lag for a topic across one or more consumer groups.
+type TopicLag struct {
+	Topic    string
+	Groups   []GroupLag
+	TotalLag int64
+}
+
+type lagCacheEntry struct {
+	result    *TopicLag
+	expiresAt time.Time
+}
+
+// lagCache memoizes getTopicLag results for a short TTL so repeated HTTP
+// hits and the periodic cluster refresh don't hammer the brokers with
+// ListConsumerGroupOffsets calls.
+type lagCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]lagCacheEntry
+}
+
+func newLagCache(ttl time.Duration) *lagCache {
+	return &lagCache{ttl: ttl, entries: make(map[string]lagCacheEntry)}
+}
+
+func lagCacheKey(topic string, groups []string) string {
+	sorted := append([]string(nil), groups...)
+	sort.Strings(sorted)
+	return topic + "|" + strings.Join(sorted, ",")
+}
+
+func (c *lagCache) get(topic string, groups []string) (*TopicLag, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[lagCacheKey(topic, groups)]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *lagCache) set(topic string, groups []string, result *TopicLag) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[lagCacheKey(topic, groups)] = lagCacheEntry{
+		result:    result,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+// getTopicLag computes per-partition consumer lag for a topic. If groups is
+// empty, consumer groups subscribed to the topic are discovered via the
+// admin API. Results are cached for s.config.LagCacheTTL.
+func (s *Server) getTopicLag(topic string, groups []string) (*TopicLag, error) {
+	if cached, ok := s.lagCache.get(topic, groups); ok {
+		return cached, nil
+	}
+	requestedGroups := groups
+
+	if len(groups) == 0 {
+		discovered, err := s.discoverConsumerGroups(topic)
+		if err != nil {
+			return nil, fmt.Errorf("discover consumer groups for topic %s: %w", topic, err)
+		}
+		groups = discovered
+	}
+
+	partitions, err := s.kafkaConn.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions for topic %s: %w", topic, err)
+	}
+
+	logEndOffsets := make(map[int32]int64, len(partitions))
+	for _, p := range partitions {
+		offset, err := s.kafkaConn.GetOffset(topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("get log end offset for %s/%d: %w", topic, p, err)
+		}
+		logEndOffsets[p] = offset
+	}
+
+	result := &TopicLag{Topic: topic}
+	for _, group := range groups {
+		groupLag, err := s.getGroupLag(group, topic, partitions, logEndOffsets)
+		if err != nil {
+			return nil, fmt.Errorf("get offsets for group %s: %w", group, err)
+		}
+		result.Groups = append(result.Groups, *groupLag)
+		result.TotalLag += groupLag.TotalLag
+		s.metrics.observeGroupLag(topic, *groupLag)
+	}
+
+	s.lagCache.set(topic, requestedGroups, result)
+	return result, nil
+}
+
+func (s *Server) getGroupLag(group, topic string, partitions []int32, logEndOffsets map[int32]int64) (*GroupLag, error) {
+	offsets, err := s.adminClient.ListConsumerGroupOffsets(group, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, err
+	}
+	block := offsets.Blocks[topic]
+
+	groupLag := &GroupLag{Group: group}
+	for _, p := range partitions {
+		logEnd := logEndOffsets[p]
+		committed := int64(-1)
+		if b, ok := block[p]; ok {
+			committed = b.Offset
+		}
+
+		lag := logEnd
+		if committed >= 0 && logEnd > committed {
+			lag = logEnd - committed
+		} else if committed >= 0 {
+			lag = 0
+		}
+
+		groupLag.Partitions = append(groupLag.Partitions, PartitionLag{
+			Partition:       p,
+			LogEndOffset:    logEnd,
+			CommittedOffset: committed,
+			Lag:             lag,
+		})
+		groupLag.TotalLag += lag
+	}
+
+	return groupLag, nil
+}
+
+// discoverConsumerGroups lists consumer groups subscribed to topic by
+// inspecting each group's member assignments.
+func (s *Server) discoverConsumerGroups(topic string) ([]string, error) {
+	groupStates, err := s.adminClient.ListConsumerGroups()
+	if err != nil {
+		return nil, err
+	}
+	if len(groupStates) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(groupStates))
+	for name := range groupStates {
+		names = append(names, name)
+	}
+
+	descriptions, err := s.adminClient.DescribeConsumerGroups(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []string
+	for _, desc := range descriptions {
+		if groupSubscribesToTopic(desc, topic) {
+			matched = append(matched, desc.GroupId)
+		}
+	}
+	return matched, nil
+}
+
+func groupSubscribesToTopic(desc *sarama.GroupDescription, topic string) bool {
+	for _, member := range desc.Members {
+		assignment, err := member.GetMemberAssignment()
+		if err != nil {
+			continue
+		}
+		if _, ok := assignment.Topics[topic]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/umerfarok/kafka-live-dashboard/config"
+)
+
+// configureLogging installs a leveled slog logger as the process default,
+// replacing the dashboard's previous unleveled log.Println/log.Printf
+// calls.
+func configureLogging(cfg *config.Config) {
+	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: parseLogLevel(cfg.LogLevel)})
+	slog.SetDefault(slog.New(handler))
+}
+
+func parseLogLevel(raw string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
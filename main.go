@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -14,6 +18,9 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/samuel/go-zookeeper/zk"
 	"github.com/umerfarok/kafka-live-dashboard/config"
+	"github.com/umerfarok/kafka-live-dashboard/decoder"
+	"github.com/umerfarok/kafka-live-dashboard/filter"
+	"github.com/umerfarok/kafka-live-dashboard/streaming"
 )
 
 var upgrader = websocket.Upgrader{
@@ -49,33 +56,71 @@ type BrokerInfo struct {
 }
 
 type Server struct {
-	config        *config.Config
-	kafkaConn     sarama.Client
-	zkConn        *zk.Conn
-	clusterStatus *ClusterStatus
-	mu            sync.Mutex
+	config          *config.Config
+	kafkaConn       sarama.Client
+	adminClient     sarama.ClusterAdmin
+	zkConn          *zk.Conn
+	clusterStatus   *ClusterStatus
+	clusterStatusAt time.Time
+	lagCache        *lagCache
+	decoders        *decoder.Registry
+	metrics         *metrics
+	mu              sync.Mutex
 }
 
-func NewServer(config *config.Config) (*Server, error) {
+func NewServer(cfg *config.Config) (*Server, error) {
 	kafkaConfig := sarama.NewConfig()
 	kafkaConfig.Version = sarama.V2_6_0_0
-	kafkaConn, err := sarama.NewClient(strings.Split(config.KafkaBrokers, ","), kafkaConfig)
+	if err := applySecurity(kafkaConfig, cfg); err != nil {
+		return nil, fmt.Errorf("configure kafka security: %w", err)
+	}
+
+	kafkaConn, err := sarama.NewClient(strings.Split(cfg.KafkaBrokers, ","), kafkaConfig)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("connect to kafka: %w", err)
 	}
 
-	zkConn, _, err := zk.Connect(strings.Split(config.ZookeeperNodes, ","), time.Second)
+	adminClient, err := sarama.NewClusterAdminFromClient(kafkaConn)
 	if err != nil {
-		return nil, err
+		kafkaConn.Close()
+		return nil, fmt.Errorf("create kafka admin client: %w", err)
 	}
 
-	return &Server{
-		config:    config,
-		kafkaConn: kafkaConn,
-		zkConn:    zkConn,
-	}, nil
-}
+	decoders, err := buildDecoderRegistry(cfg)
+	if err != nil {
+		adminClient.Close()
+		kafkaConn.Close()
+		return nil, fmt.Errorf("build decoder registry: %w", err)
+	}
 
+	s := &Server{
+		config:      cfg,
+		kafkaConn:   kafkaConn,
+		adminClient: adminClient,
+		lagCache:    newLagCache(cfg.LagCacheTTL),
+		decoders:    decoders,
+		metrics:     newMetrics(),
+	}
+
+	// Zookeeper is only required when the backend is "zookeeper"; for
+	// "auto" it is an optional fallback if the Kafka admin API is
+	// unreachable, and for "kafka" it is never dialed.
+	if cfg.MetadataBackend != config.BackendKafka {
+		zkConn, _, err := zk.Connect(strings.Split(cfg.ZookeeperNodes, ","), time.Second)
+		if err != nil {
+			if cfg.MetadataBackend == config.BackendZookeeper {
+				adminClient.Close()
+				kafkaConn.Close()
+				return nil, fmt.Errorf("connect to zookeeper: %w", err)
+			}
+			slog.Warn("zookeeper unavailable, continuing with kafka-only metadata", "error", err)
+		} else {
+			s.zkConn = zkConn
+		}
+	}
+
+	return s, nil
+}
 
 func (s *Server) startTopicRefresher() {
 	ticker := time.NewTicker(5 * time.Minute)
@@ -89,7 +134,6 @@ func (s *Server) startTopicRefresher() {
 	}()
 }
 
-
 func (s *Server) serveTopicMetrics(w http.ResponseWriter, r *http.Request, topicName string) {
 	partitions, replication, active, messages, lag, throughput, err := s.getTopicMetrics(topicName)
 	if err != nil {
@@ -97,6 +141,12 @@ func (s *Server) serveTopicMetrics(w http.ResponseWriter, r *http.Request, topic
 		return
 	}
 
+	lagDetail, err := s.getTopicLag(topicName, r.URL.Query()["group"])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to get lag for topic %s: %v", topicName, err), http.StatusInternalServerError)
+		return
+	}
+
 	topicMetrics := struct {
 		Partitions  int
 		Replication int
@@ -104,6 +154,7 @@ func (s *Server) serveTopicMetrics(w http.ResponseWriter, r *http.Request, topic
 		Messages    int64
 		Lag         int64
 		Throughput  float64
+		LagDetail   *TopicLag
 	}{
 		Partitions:  partitions,
 		Replication: replication,
@@ -111,6 +162,7 @@ func (s *Server) serveTopicMetrics(w http.ResponseWriter, r *http.Request, topic
 		Messages:    messages,
 		Lag:         lag,
 		Throughput:  throughput,
+		LagDetail:   lagDetail,
 	}
 
 	jsonBytes, err := json.Marshal(topicMetrics)
@@ -122,6 +174,43 @@ func (s *Server) serveTopicMetrics(w http.ResponseWriter, r *http.Request, topic
 	w.Write(jsonBytes)
 }
 
+// maxPeekMessages bounds the "n" query parameter on /topics/{name}/peek.
+// peekTopic allocates its result slice with capacity n up front, so without
+// a cap a client-supplied n (e.g. a few billion) could force a multi-GB
+// allocation before a single message is read or the per-partition timeout
+// has any chance to apply.
+const maxPeekMessages = 5000
+
+func (s *Server) serveTopicPeek(w http.ResponseWriter, r *http.Request, topicName string) {
+	n := 50
+	if raw := r.URL.Query().Get("n"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		if parsed > maxPeekMessages {
+			http.Error(w, fmt.Sprintf("n must be at most %d", maxPeekMessages), http.StatusBadRequest)
+			return
+		}
+		n = parsed
+	}
+
+	messages, err := s.peekTopic(r.Context(), topicName, n)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to peek topic %s: %v", topicName, err), http.StatusInternalServerError)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(messages)
+	if err != nil {
+		http.Error(w, "Failed to marshal peek results", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(jsonBytes)
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
@@ -139,12 +228,19 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		s.serveTopicList(w, r)
 		return
 	} else if strings.HasPrefix(r.URL.Path, "/topics/") {
-		topicName := strings.TrimPrefix(r.URL.Path, "/topics/")
-		s.serveTopicMetrics(w, r, topicName)
+		rest := strings.TrimPrefix(r.URL.Path, "/topics/")
+		if topicName, ok := strings.CutSuffix(rest, "/peek"); ok {
+			s.serveTopicPeek(w, r, topicName)
+			return
+		}
+		s.serveTopicMetrics(w, r, rest)
 		return
 	} else if r.URL.Path == "/ws" {
 		s.serveWebSocket(w, r)
 		return
+	} else if r.URL.Path == "/metrics" {
+		s.serveMetrics(w, r)
+		return
 	}
 
 	http.NotFound(w, r)
@@ -173,41 +269,100 @@ func (s *Server) serveTopicList(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) serveWebSocket(w http.ResponseWriter, r *http.Request) {
+	topic := r.URL.Query().Get("topic")
+	if topic == "" {
+		http.Error(w, "Topic not specified", http.StatusBadRequest)
+		return
+	}
+
+	position, err := streaming.ParsePosition(r.URL.Query().Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	messageFilter, err := buildMessageFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println("WebSocket upgrade error:", err)
+		slog.Error("websocket upgrade failed", "error", err)
 		return
 	}
 	defer conn.Close()
 
-	topic := r.URL.Query().Get("topic")
-	if topic == "" {
-		http.Error(w, "Topic not specified", http.StatusBadRequest)
-		return
+	s.handleWebSocket(conn, streaming.Options{
+		Topic:  topic,
+		Group:  r.URL.Query().Get("group"),
+		From:   position,
+		Filter: messageFilter,
+	})
+}
+
+// buildMessageFilter compiles a streaming.FilterFunc from a websocket
+// request's query parameters: the shorthand/expression predicates in
+// filter.FromQuery, ANDed with sampling from a "sample=1/N" parameter.
+func buildMessageFilter(query url.Values) (streaming.FilterFunc, error) {
+	predicate, err := filter.FromQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	sampleRate, err := filter.ParseSampleRate(query.Get("sample"))
+	if err != nil {
+		return nil, err
 	}
+	sample := filter.Sample(sampleRate)
 
-	s.handleWebSocket(conn, topic)
+	return func(e streaming.Envelope) bool {
+		record := filter.Record{
+			Key:       e.Key,
+			Value:     e.Value,
+			Headers:   e.Headers,
+			Partition: e.Partition,
+			Offset:    e.Offset,
+			Timestamp: e.Timestamp,
+		}
+		return predicate(record) && sample(record)
+	}, nil
 }
 
+// clusterStatusTTL bounds how stale s.clusterStatus may be before
+// updateClusterStatus refreshes it again. Refreshing only once (on first
+// use) would leave /metrics and the status endpoints reporting whatever the
+// cluster looked like at process start forever, even though the topic
+// refresher ticks every 5 minutes.
+const clusterStatusTTL = 5 * time.Minute
+
 func (s *Server) updateClusterStatus() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	if s.clusterStatus == nil {
 		s.clusterStatus = &ClusterStatus{}
-		s.fetchClusterMetadata()
 	}
+	if time.Since(s.clusterStatusAt) < clusterStatusTTL {
+		return
+	}
+	s.fetchClusterMetadata()
+	s.clusterStatusAt = time.Now()
 }
 func (s *Server) fetchClusterMetadata() {
+	start := time.Now()
+	defer func() { s.metrics.metadataRefreshSeconds.Observe(time.Since(start).Seconds()) }()
+
 	topics, err := s.getTopics()
 	if err != nil {
-		log.Println("Failed to get topics:", err)
+		slog.Error("failed to get topics", "error", err)
 		return
 	}
 
 	brokers, err := s.getBrokers()
 	if err != nil {
-		log.Println("Failed to get brokers:", err)
+		slog.Error("failed to get brokers", "error", err)
 		return
 	}
 
@@ -222,7 +377,7 @@ func (s *Server) fetchClusterMetadata() {
 			defer wg.Done()
 			partitions, replication, active, messages, lag, throughput, err := s.getTopicMetrics(topic)
 			if err != nil {
-				log.Printf("Failed to get metrics for topic %s: %v", topic, err)
+				slog.Error("failed to get topic metrics", "topic", topic, "error", err)
 				return
 			}
 			topicStatus[i] = TopicStatus{
@@ -250,7 +405,37 @@ func (s *Server) fetchClusterMetadata() {
 	s.clusterStatus.Brokers = brokers
 }
 
+// getTopics lists all topic names. With MetadataBackend "auto" it prefers
+// the Kafka admin API and falls back to Zookeeper if that call fails and a
+// Zookeeper connection is available.
 func (s *Server) getTopics() ([]string, error) {
+	if s.config.MetadataBackend != config.BackendZookeeper {
+		topics, err := s.getTopicsKafka()
+		if err == nil {
+			return topics, nil
+		}
+		if s.config.MetadataBackend == config.BackendKafka || s.zkConn == nil {
+			return nil, err
+		}
+		slog.Warn("kafka topic listing failed, falling back to zookeeper", "error", err)
+	}
+	return s.getTopicsZK()
+}
+
+func (s *Server) getTopicsKafka() ([]string, error) {
+	topicDetails, err := s.adminClient.ListTopics()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]string, 0, len(topicDetails))
+	for name := range topicDetails {
+		topics = append(topics, name)
+	}
+	return topics, nil
+}
+
+func (s *Server) getTopicsZK() ([]string, error) {
 	children, _, err := s.zkConn.Children("/brokers/topics")
 	if err != nil {
 		return nil, err
@@ -258,7 +443,48 @@ func (s *Server) getTopics() ([]string, error) {
 	return children, nil
 }
 
+// getBrokers lists the cluster's brokers, following the same
+// Kafka-then-Zookeeper fallback as getTopics.
 func (s *Server) getBrokers() ([]BrokerInfo, error) {
+	if s.config.MetadataBackend != config.BackendZookeeper {
+		brokers, err := s.getBrokersKafka()
+		if err == nil {
+			return brokers, nil
+		}
+		if s.config.MetadataBackend == config.BackendKafka || s.zkConn == nil {
+			return nil, err
+		}
+		slog.Warn("kafka broker listing failed, falling back to zookeeper", "error", err)
+	}
+	return s.getBrokersZK()
+}
+
+func (s *Server) getBrokersKafka() ([]BrokerInfo, error) {
+	brokers, _, err := s.adminClient.DescribeCluster()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]BrokerInfo, 0, len(brokers))
+	for _, broker := range brokers {
+		host, portStr, err := net.SplitHostPort(broker.Addr())
+		if err != nil {
+			return nil, fmt.Errorf("parse broker address %q: %w", broker.Addr(), err)
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, fmt.Errorf("parse broker port %q: %w", portStr, err)
+		}
+		result = append(result, BrokerInfo{
+			ID:       broker.ID(),
+			Hostname: host,
+			Port:     int32(port),
+		})
+	}
+	return result, nil
+}
+
+func (s *Server) getBrokersZK() ([]BrokerInfo, error) {
 	brokerIDs, _, err := s.zkConn.Children("/brokers/ids")
 	if err != nil {
 		return nil, err
@@ -293,90 +519,122 @@ func (s *Server) getBrokers() ([]BrokerInfo, error) {
 }
 
 func (s *Server) getTopicMetrics(topic string) (int, int, bool, int64, int64, float64, error) {
-	var wg sync.WaitGroup
-	wg.Add(3)
+	partitions, replication, err := s.getTopicMetadata(topic)
+	if err != nil {
+		return 0, 0, false, 0, 0, 0, err
+	}
 
-	var partitions int
-	var replication int
-	var active bool
-	var messages int64
-	var lag int64
-	var throughput float64
-	var err error
+	active, messages, throughput, err := s.getTopicActivityMetrics(topic)
+	if err != nil {
+		return 0, 0, false, 0, 0, 0, err
+	}
 
-	go func() {
-		defer wg.Done()
-		partitions, err = s.getPartitionCount(topic)
-	}()
+	lag := s.topicAggregateLag(topic)
 
-	go func() {
-		defer wg.Done()
-		replication, err = s.getReplicationFactor(topic)
-	}()
+	return partitions, replication, active, messages, lag, throughput, nil
+}
 
-	go func() {
-		defer wg.Done()
-		active, messages, lag, throughput, err = s.getTopicActivityMetrics(topic)
-	}()
+// topicAggregateLag returns the total consumer lag across all consumer
+// groups subscribed to topic. Lag computation is best-effort: a failure
+// (e.g. no reachable consumer groups) is logged and treated as zero lag
+// rather than failing the whole metrics call.
+func (s *Server) topicAggregateLag(topic string) int64 {
+	topicLag, err := s.getTopicLag(topic, nil)
+	if err != nil {
+		slog.Error("failed to compute topic lag", "topic", topic, "error", err)
+		return 0
+	}
+	return topicLag.TotalLag
+}
 
-	wg.Wait()
+// getTopicMetadata returns a topic's partition count and replication factor
+// in a single round trip, following the same Kafka-then-Zookeeper fallback
+// as getTopics.
+func (s *Server) getTopicMetadata(topic string) (int, int, error) {
+	if s.config.MetadataBackend != config.BackendZookeeper {
+		partitions, replication, err := s.getTopicMetadataKafka(topic)
+		if err == nil {
+			return partitions, replication, nil
+		}
+		if s.config.MetadataBackend == config.BackendKafka || s.zkConn == nil {
+			return 0, 0, err
+		}
+		slog.Warn("kafka metadata lookup failed, falling back to zookeeper", "topic", topic, "error", err)
+	}
+	return s.getTopicMetadataZK(topic)
+}
 
+func (s *Server) getTopicMetadataKafka(topic string) (int, int, error) {
+	metadata, err := s.adminClient.DescribeTopics([]string{topic})
 	if err != nil {
-		return 0, 0, false, 0, 0, 0, err
+		return 0, 0, err
+	}
+	if len(metadata) == 0 {
+		return 0, 0, fmt.Errorf("no metadata returned for topic %s", topic)
 	}
 
-	return partitions, replication, active, messages, lag, throughput, nil
+	topicMeta := metadata[0]
+	if topicMeta.Err != sarama.ErrNoError {
+		return 0, 0, topicMeta.Err
+	}
+
+	replication := 0
+	if len(topicMeta.Partitions) > 0 {
+		replication = len(topicMeta.Partitions[0].Replicas)
+	}
+	return len(topicMeta.Partitions), replication, nil
 }
 
-func (s *Server) getPartitionCount(topic string) (int, error) {
+func (s *Server) getTopicMetadataZK(topic string) (int, int, error) {
 	partitions, _, err := s.zkConn.Children(fmt.Sprintf("/brokers/topics/%s/partitions", topic))
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
-	return len(partitions), nil
-}
 
-func (s *Server) getReplicationFactor(topic string) (int, error) {
 	data, _, err := s.zkConn.Get(fmt.Sprintf("/brokers/topics/%s", topic))
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
 	var topicInfo struct {
 		Partitions map[string][]int32 `json:"partitions"`
 	}
 	if err := json.Unmarshal(data, &topicInfo); err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 
-	// Assume all partitions have the same replication factor
+	// Assume all partitions have the same replication factor.
+	replication := 0
 	if len(topicInfo.Partitions) > 0 {
-		return len(topicInfo.Partitions["0"]), nil
+		replication = len(topicInfo.Partitions["0"])
 	}
-	return 0, nil
+	return len(partitions), replication, nil
 }
 
-func (s *Server) getTopicActivityMetrics(topic string) (bool, int64, int64, float64, error) {
+// getTopicActivityMetrics samples partition 0 for up to 10 seconds to
+// decide whether a topic is actively receiving traffic. Consumer lag is
+// computed separately by getTopicLag against real consumer-group offsets.
+func (s *Server) getTopicActivityMetrics(topic string) (bool, int64, float64, error) {
 	consumer, err := sarama.NewConsumerFromClient(s.kafkaConn)
 	if err != nil {
-		return false, 0, 0, 0, err
+		return false, 0, 0, err
 	}
 	defer consumer.Close()
 
 	partitionConsumer, err := consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
 	if err != nil {
-		return false, 0, 0, 0, err
+		return false, 0, 0, err
 	}
 	defer partitionConsumer.Close()
 
 	var totalMessages int64
-	var totalLag int64
 	var totalMessages10s int64
 	for i := 0; i < 10; i++ {
 		select {
-		case message := <-partitionConsumer.Messages():
+		case msg := <-partitionConsumer.Messages():
 			totalMessages++
-			totalLag += message.Offset
+			s.metrics.topicMessagesInTotal.WithLabelValues(topic).Inc()
+			s.metrics.topicBytesInTotal.WithLabelValues(topic).Add(float64(len(msg.Value)))
 		case <-time.After(1 * time.Second):
 			totalMessages10s = totalMessages
 			totalMessages = 0
@@ -384,46 +642,77 @@ func (s *Server) getTopicActivityMetrics(topic string) (bool, int64, int64, floa
 		}
 	}
 
-	return totalMessages10s > 0, totalMessages, totalLag, float64(totalMessages10s) / 10.0, nil
+	return totalMessages10s > 0, totalMessages, float64(totalMessages10s) / 10.0, nil
 }
-func (s *Server) handleWebSocket(conn *websocket.Conn, topic string) {
-	consumer, err := sarama.NewConsumerFromClient(s.kafkaConn)
-	if err != nil {
-		log.Println("Failed to create consumer:", err)
-		return
-	}
-	defer consumer.Close()
 
-	partitionConsumer, err := consumer.ConsumePartition(topic, 0, sarama.OffsetNewest)
-	if err != nil {
-		log.Println("Failed to start consumer for partition:", err)
-		return
-	}
-	defer partitionConsumer.Close()
+// handleWebSocket streams every partition of a topic to conn as JSON
+// envelopes, fanning in via a streaming.Stream. It also reads from conn for
+// the lifetime of the connection so clients can send streaming.Control
+// messages (pause/resume/seek) without reconnecting.
+func (s *Server) handleWebSocket(conn *websocket.Conn, opts streaming.Options) {
+	opts.Decode = s.decoders.Decode
+	stream := streaming.NewStream(s.kafkaConn, opts)
+
+	s.metrics.wsConnections.Inc()
+	defer s.metrics.wsConnections.Dec()
 
-	done := make(chan struct{})
-	defer close(done)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	go func() {
+		if err := stream.Run(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("streaming run failed", "topic", opts.Topic, "error", err)
+		}
+	}()
+
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
 		for {
 			select {
-			case message := <-partitionConsumer.Messages():
-				err := conn.WriteMessage(websocket.TextMessage, message.Value)
+			case <-ctx.Done():
+				return
+			case envelope, ok := <-stream.Messages():
+				if !ok {
+					return
+				}
+				payload, err := json.Marshal(envelope)
 				if err != nil {
-					log.Println("WebSocket write error:", err)
+					slog.Error("websocket envelope marshal failed", "error", err)
+					continue
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+					slog.Error("websocket write failed", "error", err)
 					return
 				}
-			case <-done:
-				return
+				s.metrics.wsMessagesSentTotal.Inc()
 			}
 		}
 	}()
-	_, _, err = conn.ReadMessage()
-	if err != nil {
-		if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-			log.Println("WebSocket read error:", err)
+
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				slog.Error("websocket read failed", "error", err)
+			}
+			break
+		}
+
+		var ctrl streaming.Control
+		if err := json.Unmarshal(raw, &ctrl); err != nil {
+			slog.Warn("invalid websocket control message", "error", err)
+			continue
+		}
+
+		select {
+		case stream.Control() <- ctrl:
+		case <-ctx.Done():
 		}
 	}
+
+	cancel()
+	<-writeDone
 }
 func mustAtoi(s string) int {
 	i, err := strconv.Atoi(s)
@@ -436,18 +725,22 @@ func mustAtoi(s string) int {
 func main() {
 	config, err := config.NewConfig()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		slog.Error("failed to load config", "error", err)
+		os.Exit(1)
 	}
+	configureLogging(config)
 
 	server, err := NewServer(config)
 	server.startTopicRefresher()
 	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+		slog.Error("failed to create server", "error", err)
+		os.Exit(1)
 	}
 
 	http.Handle("/", server)
-	log.Printf("Starting server on :%s\n", config.HTTPPort)
+	slog.Info("starting server", "port", config.HTTPPort)
 	if err := http.ListenAndServe(":"+config.HTTPPort, nil); err != nil {
-		log.Fatalf("ListenAndServe error: %v", err)
+		slog.Error("listen and serve failed", "error", err)
+		os.Exit(1)
 	}
 }
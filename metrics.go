@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the Prometheus collectors exposed on /metrics, scoped to
+// their own registry rather than the global one so tests (and multiple
+// Servers in one process) don't collide.
+type metrics struct {
+	topicPartitions        *prometheus.GaugeVec
+	topicReplicationFactor *prometheus.GaugeVec
+	topicMessagesInTotal   *prometheus.CounterVec
+	topicBytesInTotal      *prometheus.CounterVec
+	consumerGroupLag       *prometheus.GaugeVec
+	brokerUp               *prometheus.GaugeVec
+
+	wsConnections          prometheus.Gauge
+	wsMessagesSentTotal    prometheus.Counter
+	metadataRefreshSeconds prometheus.Histogram
+
+	handler http.Handler
+}
+
+func newMetrics() *metrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+
+	return &metrics{
+		topicPartitions: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_topic_partitions",
+			Help: "Number of partitions for a topic.",
+		}, []string{"topic"}),
+		topicReplicationFactor: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_topic_replication_factor",
+			Help: "Replication factor for a topic.",
+		}, []string{"topic"}),
+		topicMessagesInTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_topic_messages_in_total",
+			Help: "Messages observed for a topic by the dashboard's activity sampler.",
+		}, []string{"topic"}),
+		topicBytesInTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "kafka_topic_bytes_in_total",
+			Help: "Message value bytes observed for a topic by the dashboard's activity sampler.",
+		}, []string{"topic"}),
+		consumerGroupLag: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_consumergroup_lag",
+			Help: "Consumer group lag for a topic partition.",
+		}, []string{"group", "topic", "partition"}),
+		brokerUp: factory.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "kafka_broker_up",
+			Help: "1 if the broker was present in the last successful metadata refresh.",
+		}, []string{"id", "host"}),
+
+		wsConnections: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "ws_connections",
+			Help: "Currently open dashboard websocket connections.",
+		}),
+		wsMessagesSentTotal: factory.NewCounter(prometheus.CounterOpts{
+			Name: "ws_messages_sent_total",
+			Help: "Messages forwarded to dashboard websocket clients.",
+		}),
+		metadataRefreshSeconds: factory.NewHistogram(prometheus.HistogramOpts{
+			Name: "metadata_refresh_duration_seconds",
+			Help: "Time spent refreshing cluster metadata.",
+		}),
+
+		handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// observeClusterStatus updates the topic/broker gauges from an
+// already-computed ClusterStatus, so scraping /metrics reuses the cached
+// cluster refresh instead of issuing its own admin calls.
+func (m *metrics) observeClusterStatus(status *ClusterStatus) {
+	if status == nil {
+		return
+	}
+
+	for _, topic := range status.Topics {
+		m.topicPartitions.WithLabelValues(topic.Name).Set(float64(topic.Partitions))
+		m.topicReplicationFactor.WithLabelValues(topic.Name).Set(float64(topic.Replication))
+	}
+
+	m.brokerUp.Reset()
+	for _, broker := range status.Brokers {
+		m.brokerUp.WithLabelValues(strconv.Itoa(int(broker.ID)), broker.Hostname).Set(1)
+	}
+}
+
+// observeGroupLag records per-partition consumer lag for a topic/group
+// pair, called from the lag subsystem once it has already computed
+// TopicLag for some other reason (an HTTP hit or a cluster refresh).
+func (m *metrics) observeGroupLag(topic string, group GroupLag) {
+	for _, partitionLag := range group.Partitions {
+		m.consumerGroupLag.
+			WithLabelValues(group.Group, topic, strconv.Itoa(int(partitionLag.Partition))).
+			Set(float64(partitionLag.Lag))
+	}
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	s.updateClusterStatus()
+	s.metrics.observeClusterStatus(s.clusterStatus)
+	s.metrics.handler.ServeHTTP(w, r)
+}
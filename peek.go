@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/umerfarok/kafka-live-dashboard/streaming"
+)
+
+// peekPartitionTimeout bounds how long peekTopic waits for a single
+// partition's remaining messages. Compacted topics, transaction
+// commit/abort control records, and retention-deleted segments can all
+// leave gaps between oldest and newest, so fewer messages than the offset
+// range implies may ever arrive; without a deadline the read would block
+// forever.
+const peekPartitionTimeout = 5 * time.Second
+
+// peekTopic returns up to n of the most recent messages across all of a
+// topic's partitions, decoded with the topic's configured decoder. ctx
+// bounds how long it waits on a slow or gappy partition.
+func (s *Server) peekTopic(ctx context.Context, topic string, n int) ([]streaming.Envelope, error) {
+	partitions, err := s.kafkaConn.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions for topic %s: %w", topic, err)
+	}
+	if len(partitions) == 0 {
+		return nil, nil
+	}
+
+	consumer, err := sarama.NewConsumerFromClient(s.kafkaConn)
+	if err != nil {
+		return nil, fmt.Errorf("create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	perPartition := int64(n/len(partitions)) + 1
+	results := make([]streaming.Envelope, 0, n)
+
+	for _, partition := range partitions {
+		if len(results) >= n {
+			break
+		}
+
+		oldest, err := s.kafkaConn.GetOffset(topic, partition, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("get oldest offset for %s/%d: %w", topic, partition, err)
+		}
+		newest, err := s.kafkaConn.GetOffset(topic, partition, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("get newest offset for %s/%d: %w", topic, partition, err)
+		}
+
+		start := newest - perPartition
+		if start < oldest {
+			start = oldest
+		}
+		if start >= newest {
+			continue
+		}
+
+		pc, err := consumer.ConsumePartition(topic, partition, start)
+		if err != nil {
+			return nil, fmt.Errorf("consume %s/%d from offset %d: %w", topic, partition, start, err)
+		}
+
+		deadline := time.NewTimer(peekPartitionTimeout)
+	partitionLoop:
+		for len(results) < n {
+			select {
+			case msg := <-pc.Messages():
+				results = append(results, streaming.ToEnvelope(topic, msg, s.decoders.Decode))
+				if msg.Offset >= newest-1 {
+					break partitionLoop
+				}
+			case <-deadline.C:
+				break partitionLoop
+			case <-ctx.Done():
+				deadline.Stop()
+				pc.Close()
+				return nil, ctx.Err()
+			}
+		}
+		deadline.Stop()
+		pc.Close()
+	}
+
+	return results, nil
+}
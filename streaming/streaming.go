@@ -0,0 +1,503 @@
+// Package streaming fans a Kafka topic's partitions into a single bounded
+// channel of JSON-friendly envelopes, with support for consumer groups,
+// arbitrary start positions, and an in-band control channel for
+// pause/resume/seek without reconnecting.
+package streaming
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// PositionKind selects where a stream starts (or seeks to) within a
+// topic's partitions.
+type PositionKind string
+
+const (
+	PositionLatest    PositionKind = "latest"
+	PositionEarliest  PositionKind = "earliest"
+	PositionTimestamp PositionKind = "timestamp"
+	PositionOffset    PositionKind = "offset"
+)
+
+// Position is a parsed `from=` value.
+type Position struct {
+	Kind      PositionKind
+	Timestamp int64 // milliseconds since epoch, when Kind == PositionTimestamp
+	Offset    int64 // when Kind == PositionOffset
+}
+
+// ParsePosition parses the `from` query parameter: "earliest", "latest"
+// (the default), "timestamp:<ms>", or "offset:<n>".
+func ParsePosition(raw string) (Position, error) {
+	switch {
+	case raw == "" || raw == string(PositionLatest):
+		return Position{Kind: PositionLatest}, nil
+	case raw == string(PositionEarliest):
+		return Position{Kind: PositionEarliest}, nil
+	case strings.HasPrefix(raw, "timestamp:"):
+		ms, err := strconv.ParseInt(strings.TrimPrefix(raw, "timestamp:"), 10, 64)
+		if err != nil {
+			return Position{}, fmt.Errorf("invalid timestamp in from=%q: %w", raw, err)
+		}
+		return Position{Kind: PositionTimestamp, Timestamp: ms}, nil
+	case strings.HasPrefix(raw, "offset:"):
+		offset, err := strconv.ParseInt(strings.TrimPrefix(raw, "offset:"), 10, 64)
+		if err != nil {
+			return Position{}, fmt.Errorf("invalid offset in from=%q: %w", raw, err)
+		}
+		return Position{Kind: PositionOffset, Offset: offset}, nil
+	default:
+		return Position{}, fmt.Errorf("unrecognized from=%q (want earliest, latest, timestamp:<ms>, or offset:<n>)", raw)
+	}
+}
+
+// Envelope is the JSON shape written to the websocket for every consumed
+// message.
+type Envelope struct {
+	Partition int32             `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Timestamp time.Time         `json:"timestamp"`
+	Key       any               `json:"key,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Value     any               `json:"value"`
+}
+
+// DecodeFunc turns a raw key or value payload into a JSON-friendly
+// representation. It matches decoder.Registry's Decode method signature so
+// a *decoder.Registry can be passed directly as Options.Decode.
+type DecodeFunc func(topic string, isKey bool, raw []byte) (any, error)
+
+// ControlAction is an in-band instruction a client can send over the
+// websocket to steer a running stream.
+type ControlAction string
+
+const (
+	ActionPause  ControlAction = "pause"
+	ActionResume ControlAction = "resume"
+	ActionSeek   ControlAction = "seek"
+)
+
+// Control is the JSON shape clients send over the websocket to steer a
+// running stream without reconnecting.
+type Control struct {
+	Action ControlAction `json:"action"`
+	From   string        `json:"from,omitempty"` // required for ActionSeek; same syntax as the `from` query param
+}
+
+// Options configures a Stream.
+type Options struct {
+	Topic string
+	Group string // empty means each websocket gets its own direct partition consumers
+	From  Position
+	// BufferSize bounds the outbound message channel. When full, the
+	// oldest buffered message is dropped to make room for the newest one
+	// so a slow websocket client falls behind rather than stalling the
+	// consumer.
+	BufferSize int
+	// Decode, when set, is applied to each message's key and value before
+	// it is forwarded. A nil Decode forwards raw bytes unchanged
+	// (base64-encoded by encoding/json).
+	Decode DecodeFunc
+	// Filter, when set, is evaluated against every decoded Envelope before
+	// it is forwarded; envelopes for which it returns false are dropped
+	// without consuming buffer space. A nil Filter forwards everything.
+	Filter FilterFunc
+}
+
+// FilterFunc reports whether an Envelope should be forwarded to the
+// websocket client. It runs after decoding, so it sees the same
+// representation the client would receive.
+type FilterFunc func(Envelope) bool
+
+// Stream consumes every partition of a topic and exposes the messages as a
+// single channel of Envelopes, along with a channel clients can use to
+// pause, resume, or seek the stream.
+type Stream struct {
+	client sarama.Client
+	topic  string
+	group  string
+	decode DecodeFunc
+	filter FilterFunc
+
+	mu       sync.Mutex
+	position Position
+
+	paused   atomic.Bool
+	messages chan Envelope
+	control  chan Control
+}
+
+// NewStream builds a Stream. Call Run to start consuming.
+func NewStream(client sarama.Client, opts Options) *Stream {
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+
+	return &Stream{
+		client:   client,
+		topic:    opts.Topic,
+		group:    opts.Group,
+		decode:   opts.Decode,
+		filter:   opts.Filter,
+		position: opts.From,
+		messages: make(chan Envelope, bufferSize),
+		control:  make(chan Control, 8),
+	}
+}
+
+// Messages returns the channel of consumed, fanned-in messages.
+func (st *Stream) Messages() <-chan Envelope {
+	return st.messages
+}
+
+// Control returns the channel clients send pause/resume/seek instructions
+// on.
+func (st *Stream) Control() chan<- Control {
+	return st.control
+}
+
+// Run consumes the topic until ctx is canceled or an unrecoverable error
+// occurs.
+func (st *Stream) Run(ctx context.Context) error {
+	if st.group != "" {
+		return st.runGroup(ctx)
+	}
+	return st.runDirect(ctx)
+}
+
+func (st *Stream) currentPosition() Position {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.position
+}
+
+func (st *Stream) setPosition(p Position) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.position = p
+}
+
+func (st *Stream) isPaused() bool {
+	return st.paused.Load()
+}
+
+// publish drops the oldest buffered envelope to make room when the
+// channel is full, so a slow reader sees "drop oldest" behavior instead of
+// blocking the consumer.
+func (st *Stream) publish(env Envelope) {
+	if st.filter != nil && !st.filter(env) {
+		return
+	}
+
+	select {
+	case st.messages <- env:
+		return
+	default:
+	}
+
+	select {
+	case <-st.messages:
+	default:
+	}
+
+	select {
+	case st.messages <- env:
+	default:
+	}
+}
+
+// ToEnvelope converts a raw Kafka message into an Envelope, applying
+// decode (if non-nil) to the key and value. Exported so REST endpoints
+// that peek at messages outside of a running Stream can produce envelopes
+// in the same shape.
+func ToEnvelope(topic string, msg *sarama.ConsumerMessage, decode DecodeFunc) Envelope {
+	var headers map[string]string
+	if len(msg.Headers) > 0 {
+		headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			headers[string(h.Key)] = string(h.Value)
+		}
+	}
+
+	return Envelope{
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Timestamp,
+		Key:       decodeField(topic, true, msg.Key, decode),
+		Headers:   headers,
+		Value:     decodeField(topic, false, msg.Value, decode),
+	}
+}
+
+func decodeField(topic string, isKey bool, raw []byte, decode DecodeFunc) any {
+	if decode == nil || raw == nil {
+		return raw
+	}
+	decoded, err := decode(topic, isKey, raw)
+	if err != nil {
+		slog.Error("streaming: decode error", "topic", topic, "error", err)
+		return raw
+	}
+	return decoded
+}
+
+// resolveOffset turns a Position into a concrete offset to start consuming
+// partition from.
+func (st *Stream) resolveOffset(partition int32, position Position) (int64, error) {
+	switch position.Kind {
+	case PositionEarliest:
+		return st.client.GetOffset(st.topic, partition, sarama.OffsetOldest)
+	case PositionLatest, "":
+		return st.client.GetOffset(st.topic, partition, sarama.OffsetNewest)
+	case PositionOffset:
+		return position.Offset, nil
+	case PositionTimestamp:
+		offset, err := st.client.GetOffset(st.topic, partition, position.Timestamp)
+		if err != nil {
+			return 0, err
+		}
+		if offset == -1 {
+			// No message at or after the timestamp: fall back to the
+			// live end of the partition rather than erroring out.
+			return st.client.GetOffset(st.topic, partition, sarama.OffsetNewest)
+		}
+		return offset, nil
+	default:
+		return 0, fmt.Errorf("unknown start position kind %q", position.Kind)
+	}
+}
+
+// runDirect consumes every partition of the topic directly, restarting
+// only when a seek control message arrives; pause/resume just toggle a
+// flag checked before forwarding each message.
+func (st *Stream) runDirect(ctx context.Context) error {
+	consumer, err := sarama.NewConsumerFromClient(st.client)
+	if err != nil {
+		return fmt.Errorf("create consumer: %w", err)
+	}
+	defer consumer.Close()
+
+	partitionCtx, cancelPartitions := context.WithCancel(ctx)
+	wg, err := st.startPartitionConsumers(partitionCtx, consumer, st.currentPosition())
+	if err != nil {
+		cancelPartitions()
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancelPartitions()
+			wg.Wait()
+			return ctx.Err()
+
+		case ctrl := <-st.control:
+			switch ctrl.Action {
+			case ActionPause:
+				st.paused.Store(true)
+			case ActionResume:
+				st.paused.Store(false)
+			case ActionSeek:
+				position, err := ParsePosition(ctrl.From)
+				if err != nil {
+					slog.Warn("streaming: ignoring invalid seek", "from", ctrl.From, "error", err)
+					continue
+				}
+
+				cancelPartitions()
+				wg.Wait()
+				st.setPosition(position)
+
+				partitionCtx, cancelPartitions = context.WithCancel(ctx)
+				wg, err = st.startPartitionConsumers(partitionCtx, consumer, position)
+				if err != nil {
+					cancelPartitions()
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (st *Stream) startPartitionConsumers(ctx context.Context, consumer sarama.Consumer, position Position) (*sync.WaitGroup, error) {
+	partitions, err := st.client.Partitions(st.topic)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions for topic %s: %w", st.topic, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, partition := range partitions {
+		offset, err := st.resolveOffset(partition, position)
+		if err != nil {
+			slog.Error("streaming: resolve start offset failed", "topic", st.topic, "partition", partition, "error", err)
+			continue
+		}
+
+		pc, err := consumer.ConsumePartition(st.topic, partition, offset)
+		if err != nil {
+			slog.Error("streaming: consume partition failed", "topic", st.topic, "partition", partition, "offset", offset, "error", err)
+			continue
+		}
+
+		wg.Add(1)
+		go st.pumpPartition(ctx, &wg, pc)
+	}
+
+	return &wg, nil
+}
+
+func (st *Stream) pumpPartition(ctx context.Context, wg *sync.WaitGroup, pc sarama.PartitionConsumer) {
+	defer wg.Done()
+	defer pc.AsyncClose()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return
+			}
+			slog.Error("streaming: partition consumer error", "error", err)
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return
+			}
+			if !st.isPaused() {
+				st.publish(ToEnvelope(st.topic, msg, st.decode))
+			}
+		}
+	}
+}
+
+// runGroup consumes the topic as part of a shared consumer group, so
+// multiple dashboard viewers on the same group split the partitions and
+// commit offsets as they go.
+func (st *Stream) runGroup(ctx context.Context) error {
+	group, err := sarama.NewConsumerGroupFromClient(st.group, st.client)
+	if err != nil {
+		return fmt.Errorf("create consumer group %s: %w", st.group, err)
+	}
+	defer group.Close()
+
+	go func() {
+		for err := range group.Errors() {
+			slog.Error("streaming: consumer group error", "group", st.group, "error", err)
+		}
+	}()
+
+	handler := &groupHandler{stream: st}
+
+	var mu sync.Mutex
+	var cancelSession context.CancelFunc
+
+	// Pause/resume/seek control messages are handled by this one
+	// goroutine for the life of the group, not inside ConsumeClaim: every
+	// partition's ConsumeClaim runs in its own goroutine, so a control
+	// message read off the shared channel by one of them would only ever
+	// affect that one partition. A seek in particular needs every
+	// partition to restart together (group.Consume only returns once all
+	// claims have), so it's applied here by canceling the active
+	// session's context, which every ConsumeClaim is watching.
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ctrl := <-st.control:
+				switch ctrl.Action {
+				case ActionPause:
+					st.paused.Store(true)
+				case ActionResume:
+					st.paused.Store(false)
+				case ActionSeek:
+					position, err := ParsePosition(ctrl.From)
+					if err != nil {
+						slog.Warn("streaming: ignoring invalid seek", "from", ctrl.From, "error", err)
+						continue
+					}
+					st.setPosition(position)
+					mu.Lock()
+					if cancelSession != nil {
+						cancelSession()
+					}
+					mu.Unlock()
+				}
+			}
+		}
+	}()
+
+	for ctx.Err() == nil {
+		sessionCtx, cancel := context.WithCancel(ctx)
+		mu.Lock()
+		cancelSession = cancel
+		mu.Unlock()
+
+		err := group.Consume(sessionCtx, []string{st.topic}, handler)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("consume group %s: %w", st.group, err)
+		}
+	}
+	return ctx.Err()
+}
+
+type groupHandler struct {
+	stream *Stream
+}
+
+// Setup runs once per session, before any of the session's per-partition
+// ConsumeClaim goroutines start, so this is where a pending seek must be
+// applied: resetting the offset here for every partition the session owns
+// guarantees they all move together. Doing this inside ConsumeClaim instead
+// would race one goroutine per assigned partition against a single shared
+// Stream.position field — whichever partition's goroutine happened to read
+// and clear it first would seek, and every other partition would find the
+// position already cleared back to PositionLatest and keep consuming from
+// its old offset.
+func (h *groupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	position := h.stream.currentPosition()
+	if position.Kind != PositionEarliest && position.Kind != PositionOffset && position.Kind != PositionTimestamp {
+		return nil
+	}
+
+	for _, partition := range session.Claims()[h.stream.topic] {
+		offset, err := h.stream.resolveOffset(partition, position)
+		if err != nil {
+			slog.Error("streaming: seek failed", "topic", h.stream.topic, "partition", partition, "error", err)
+			continue
+		}
+		session.ResetOffset(h.stream.topic, partition, offset, "")
+	}
+	h.stream.setPosition(Position{Kind: PositionLatest})
+	return nil
+}
+
+func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+func (h *groupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !h.stream.isPaused() {
+				h.stream.publish(ToEnvelope(h.stream.topic, msg, h.stream.decode))
+			}
+			session.MarkMessage(msg, "")
+		}
+	}
+}
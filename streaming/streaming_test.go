@@ -0,0 +1,146 @@
+package streaming
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeSession is a minimal sarama.ConsumerGroupSession whose Context is
+// controlled by the test, so ConsumeClaim's session.Context().Done() path
+// can be exercised without a real consumer group.
+type fakeSession struct {
+	ctx    context.Context
+	claims map[string][]int32
+
+	mu           sync.Mutex
+	resetOffsets map[int32]int64
+}
+
+func (f *fakeSession) Claims() map[string][]int32                  { return f.claims }
+func (f *fakeSession) MemberID() string                            { return "" }
+func (f *fakeSession) GenerationID() int32                         { return 0 }
+func (f *fakeSession) MarkOffset(string, int32, int64, string)     {}
+func (f *fakeSession) Commit()                                     {}
+func (f *fakeSession) MarkMessage(*sarama.ConsumerMessage, string) {}
+func (f *fakeSession) Context() context.Context                    { return f.ctx }
+func (f *fakeSession) ResetOffset(_ string, partition int32, offset int64, _ string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.resetOffsets == nil {
+		f.resetOffsets = make(map[int32]int64)
+	}
+	f.resetOffsets[partition] = offset
+}
+
+// fakeClaim is a minimal sarama.ConsumerGroupClaim backed by a plain channel.
+type fakeClaim struct {
+	topic     string
+	partition int32
+	messages  chan *sarama.ConsumerMessage
+}
+
+func (f *fakeClaim) Topic() string                            { return f.topic }
+func (f *fakeClaim) Partition() int32                         { return f.partition }
+func (f *fakeClaim) InitialOffset() int64                     { return 0 }
+func (f *fakeClaim) HighWaterMarkOffset() int64               { return 0 }
+func (f *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return f.messages }
+
+// TestConsumeClaimStopsWhenSessionCanceled guards against the seek race
+// where only the partition that happened to read a seek control message
+// off Stream.control would restart, leaving every other partition's
+// ConsumeClaim blocked on claim.Messages() forever. runGroup now cancels
+// the whole session on a seek; ConsumeClaim must notice that and return.
+func TestConsumeClaimStopsWhenSessionCanceled(t *testing.T) {
+	st := NewStream(nil, Options{Topic: "orders"})
+	handler := &groupHandler{stream: st}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx, claims: map[string][]int32{"orders": {0}}}
+	claim := &fakeClaim{topic: "orders", partition: 0, messages: make(chan *sarama.ConsumerMessage)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.ConsumeClaim(session, claim)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ConsumeClaim returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ConsumeClaim did not return after its session context was canceled")
+	}
+}
+
+// TestSetupAppliesSeekToEveryClaimedPartition guards against the seek race
+// where resetting the offset inside ConsumeClaim let whichever partition's
+// goroutine ran first "consume" the pending position, leaving every other
+// partition to resume from its old offset. Setup runs once per session,
+// before any ConsumeClaim goroutine starts, so it must reset every
+// partition the session owns in one pass.
+func TestSetupAppliesSeekToEveryClaimedPartition(t *testing.T) {
+	st := NewStream(nil, Options{Topic: "orders"})
+	st.setPosition(Position{Kind: PositionOffset, Offset: 42})
+
+	handler := &groupHandler{stream: st}
+	session := &fakeSession{ctx: context.Background(), claims: map[string][]int32{"orders": {0, 1, 2, 3}}}
+
+	if err := handler.Setup(session); err != nil {
+		t.Fatalf("Setup returned error: %v", err)
+	}
+
+	for _, partition := range []int32{0, 1, 2, 3} {
+		offset, ok := session.resetOffsets[partition]
+		if !ok {
+			t.Fatalf("partition %d: ResetOffset was not called", partition)
+		}
+		if offset != 42 {
+			t.Fatalf("partition %d: resetOffset = %d, want 42", partition, offset)
+		}
+	}
+	if got := st.currentPosition().Kind; got != PositionLatest {
+		t.Fatalf("position after seek = %q, want %q", got, PositionLatest)
+	}
+}
+
+// TestConsumeClaimDoesNotReapplySeek ensures ConsumeClaim no longer touches
+// the pending-seek state itself (that now happens once, in Setup) — it
+// should just consume normally even when a seek has already been handled.
+func TestConsumeClaimDoesNotReapplySeek(t *testing.T) {
+	st := NewStream(nil, Options{Topic: "orders"})
+	st.setPosition(Position{Kind: PositionLatest})
+
+	handler := &groupHandler{stream: st}
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx, claims: map[string][]int32{"orders": {3}}}
+	claim := &fakeClaim{topic: "orders", partition: 3, messages: make(chan *sarama.ConsumerMessage)}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.ConsumeClaim(session, claim)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ConsumeClaim returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ConsumeClaim did not return after its session context was canceled")
+	}
+
+	if len(session.resetOffsets) != 0 {
+		t.Fatalf("expected ConsumeClaim not to call ResetOffset, got %v", session.resetOffsets)
+	}
+}